@@ -0,0 +1,129 @@
+package search
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// defaultMaxEdits is the Levenshtein distance budget Query.Fuzzy uses when
+// MaxEdits isn't set, enough to tolerate a couple of typos in a short word
+// like "postgrs" -> "postgres" without matching unrelated terms.
+const defaultMaxEdits = 2
+
+// errFuzzyRegexp is returned by SearchQuery when a Query sets both Fuzzy and
+// Regexp: a regular expression has no well-defined edit distance, so the two
+// modes can't be combined.
+var errFuzzyRegexp = errors.New("search: Fuzzy and Regexp are mutually exclusive")
+
+// indexTokens records every word of text against key and adds it to the
+// index's sorted token vocabulary, so fuzzySearch can later walk the
+// vocabulary looking for words within a bounded edit distance of a query
+// term.
+func (i *Index) indexTokens(key, text string) {
+	for _, w := range wordsOf(text) {
+		if !i.tokenSeen[w] {
+			i.tokenSeen[w] = true
+			i.tokens = append(i.tokens, w)
+			i.tokensDirty = true
+		}
+		docs := i.tokenDocs[w]
+		if n := len(docs); n == 0 || docs[n-1] != key {
+			i.tokenDocs[w] = append(docs, key)
+		}
+	}
+}
+
+// wordsOf splits s into lowercase runs of letters and digits.
+func wordsOf(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// fuzzySearch finds charts whose name, keywords, or description contain a
+// word within maxEdits of some word of query, scoring each match as
+// editDistance*2 + positionPenalty so that exact (distance-0) matches always
+// outrank fuzzy ones, regardless of where in the text they occur.
+// positionPenalty is 1 when the word appears past the start of the chart's
+// name+description text and 0 otherwise, so it never outweighs one edit.
+func (i *Index) fuzzySearch(query string, maxEdits int) ([]*Result, error) {
+	if i.tokensDirty {
+		sort.Strings(i.tokens)
+		i.tokensDirty = false
+	}
+
+	best := map[string]int{}
+	for _, term := range wordsOf(query) {
+		for _, tok := range i.tokens {
+			dist := levenshtein(term, tok, maxEdits)
+			if dist > maxEdits {
+				continue
+			}
+			for _, key := range i.tokenDocs[tok] {
+				penalty := 0
+				if pos := strings.Index(i.lines[key], tok); pos > 0 {
+					penalty = 1
+				}
+				score := dist*2 + penalty
+				if cur, ok := best[key]; !ok || score < cur {
+					best[key] = score
+				}
+			}
+		}
+	}
+
+	out := make([]*Result, 0, len(best))
+	for key, score := range best {
+		out = append(out, &Result{Name: i.names[key], Score: score, Chart: i.charts[key], key: key, termFreq: i.termFreqs[key], docLen: i.docLens[key]})
+	}
+	return out, nil
+}
+
+// levenshtein returns the edit distance between a and b, or max+1 if it
+// provably exceeds max - callers only care whether a word is within budget,
+// so there's no need to finish the DP once a row's minimum blows past it.
+func levenshtein(a, b string, max int) int {
+	ar, br := []rune(a), []rune(b)
+	if diff := len(ar) - len(br); diff > max || -diff > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -0,0 +1,58 @@
+package search
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func loadFuzzyTestIndex(_ *testing.T) *Index {
+	i := NewIndex()
+	i.AddRepo("testing", &repo.IndexFile{Entries: map[string]repo.ChartVersions{
+		"postgres": {
+			{
+				URLs: []string{"http://example.com/charts/postgres-1.0.0.tgz"},
+				Metadata: &chart.Metadata{
+					Name:        "postgres",
+					Version:     "1.0.0",
+					Description: "An object-relational database",
+				},
+			},
+		},
+	}}, false)
+	return i
+}
+
+func TestSearchQueryFuzzy(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		found bool
+	}{
+		{name: "missing char", query: "postgrs", found: true},
+		{name: "transposition", query: "psotgres", found: true},
+		{name: "too many edits", query: "pstgsql", found: false},
+	}
+
+	i := loadFuzzyTestIndex(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := i.SearchQuery(Query{Term: tt.query, Fuzzy: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if found := len(results) > 0; found != tt.found {
+				t.Errorf("query %q: expected found=%v, got %v (%d results)", tt.query, tt.found, found, len(results))
+			}
+		})
+	}
+}
+
+func TestSearchQueryFuzzyAndRegexpMutuallyExclusive(t *testing.T) {
+	i := loadFuzzyTestIndex(t)
+	_, err := i.SearchQuery(Query{Term: "postgres", Fuzzy: true, Regexp: true})
+	if err != errFuzzyRegexp {
+		t.Errorf("expected errFuzzyRegexp, got %v", err)
+	}
+}
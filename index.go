@@ -0,0 +1,548 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// fields holds the per-field searchable text for a single indexed chart
+// version, used by field-scoped clauses such as "keyword:database". The
+// combined name+description blob in Index.lines still backs unqualified
+// terms.
+type fields struct {
+	name        string
+	description string
+	keywords    string
+	maintainers string
+	sources     string
+	home        string
+	appVersion  string
+}
+
+// Result is a search result.
+//
+// Score indicates how close it is to match. The lower the score, the closer
+// the match. Under the default position-based Scorer, 0 means an exact match;
+// BM25Scorer produces negative scores. It is populated from whichever Scorer
+// produced the result (see NewIndexWithScorer); RelevanceScore carries the
+// same value without the int rounding.
+type Result struct {
+	Name           string
+	Score          int
+	RelevanceScore float64
+	Chart          *repo.ChartVersion
+
+	// key is the Index's internal lookup key for this result. termFreq and
+	// docLen are that document's BM25 field-weighted term frequencies and
+	// total field length (see scorer.go), computed once at AddRepo time and
+	// copied onto the Result when it is built.
+	key      string
+	termFreq map[string]float64
+	docLen   float64
+}
+
+// Index is a searchable index of chart information.
+type Index struct {
+	lines  map[string]string
+	fields map[string]fields
+	charts map[string]*repo.ChartVersion
+	names  map[string]string
+	orders map[string]int
+
+	// postings maps a trigram to the sorted-by-insertion list of document
+	// keys whose combined searchable text contains it, letting Search
+	// narrow its candidate set before running the exact substring/regex
+	// check. See trigram.go.
+	postings map[string][]string
+
+	// tokens and tokenDocs back fuzzy search: tokens is the sorted set of
+	// unique words across every indexed chart, and tokenDocs maps each
+	// word to the documents it appears in. See fuzzy.go.
+	tokens      []string
+	tokenSeen   map[string]bool
+	tokenDocs   map[string][]string
+	tokensDirty bool
+
+	// scorer computes each Result's relevance score; see scorer.go.
+	scorer Scorer
+
+	// docFreq, totalDocLen and docCount back BM25Scorer's corpus-wide
+	// inverse document frequency and average document length. termFreqs and
+	// docLens hold each document's own weighted term frequencies and total
+	// length, attached to its Result at search time.
+	docFreq     map[string]int
+	totalDocLen float64
+	docCount    int
+	termFreqs   map[string]map[string]float64
+	docLens     map[string]float64
+
+	// versions holds every version AddRepo saw for a chart, keyed by
+	// "repo/name", regardless of whether all was set. Search uses this to
+	// resolve version constraints even when the index itself only keeps the
+	// latest version around.
+	versions map[string]repo.ChartVersions
+	expanded map[string]bool
+}
+
+// NewIndex creates a new Index using the default position-based Scorer.
+func NewIndex() *Index {
+	return NewIndexWithScorer(positionScorer{})
+}
+
+// NewIndexWithScorer creates a new Index that ranks results with scorer
+// instead of the default position-based one, e.g. NewIndexWithScorer(new
+// (BM25Scorer)) for BM25 ranking.
+func NewIndexWithScorer(scorer Scorer) *Index {
+	return &Index{
+		lines:     map[string]string{},
+		fields:    map[string]fields{},
+		charts:    map[string]*repo.ChartVersion{},
+		names:     map[string]string{},
+		orders:    map[string]int{},
+		versions:  map[string]repo.ChartVersions{},
+		expanded:  map[string]bool{},
+		postings:  map[string][]string{},
+		tokenSeen: map[string]bool{},
+		tokenDocs: map[string][]string{},
+		docFreq:   map[string]int{},
+		termFreqs: map[string]map[string]float64{},
+		docLens:   map[string]float64{},
+		scorer:    scorer,
+	}
+}
+
+// AddRepo adds a repository index to the search index.
+//
+// If all is true, every version of a chart is indexed; otherwise only the
+// first (latest, since IndexFile.SortEntries puts newest first) version is.
+func (i *Index) AddRepo(rname string, ind *repo.IndexFile, all bool) {
+	ind.SortEntries()
+	for name, ctvs := range ind.Entries {
+		if len(ctvs) == 0 {
+			continue
+		}
+
+		chartName := path.Join(rname, name)
+		i.versions[chartName] = ctvs
+		i.expanded[chartName] = all
+
+		if !all {
+			i.addVersion(chartName, name, ctvs[0], 0)
+			continue
+		}
+		for ord, ctv := range ctvs {
+			i.addVersion(chartName, name, ctv, ord)
+		}
+	}
+}
+
+// addVersion indexes a single chart version under key chartName, or
+// chartName-version for ord > 0 so multiple versions of the same chart don't
+// collide in i.lines/i.charts.
+func (i *Index) addVersion(chartName, name string, ctv *repo.ChartVersion, ord int) {
+	key := chartName
+	if ord > 0 {
+		key = fmt.Sprintf("%s-%s", chartName, ctv.Version)
+	}
+	f := fieldsOf(name, ctv)
+	i.lines[key] = indstr(chartName, ctv.Description)
+	i.fields[key] = f
+	i.charts[key] = ctv
+	i.names[key] = chartName
+	i.orders[key] = ord
+	blob := strings.Join([]string{chartName, f.name, f.description, f.keywords, f.maintainers, f.sources, f.home, f.appVersion}, " ")
+	i.indexTrigrams(key, blob)
+	i.indexTokens(key, strings.Join([]string{chartName, f.keywords, f.description}, " "))
+	i.indexBM25Stats(key, name, ctv)
+}
+
+// fieldsOf extracts the per-field searchable text for ctv, lowercased so
+// matching stays case-insensitive.
+func fieldsOf(name string, ctv *repo.ChartVersion) fields {
+	maintainers := make([]string, 0, len(ctv.Maintainers))
+	for _, m := range ctv.Maintainers {
+		maintainers = append(maintainers, m.Name)
+	}
+	return fields{
+		name:        strings.ToLower(name),
+		description: strings.ToLower(ctv.Description),
+		keywords:    indstr(ctv.Keywords...),
+		maintainers: indstr(maintainers...),
+		sources:     indstr(ctv.Sources...),
+		home:        strings.ToLower(ctv.Home),
+		appVersion:  strings.ToLower(ctv.AppVersion),
+	}
+}
+
+// indstr builds the lowercase string Search matches a plain (unqualified)
+// query against.
+func indstr(parts ...string) string {
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+// All returns all of the indexed entries.
+func (i Index) All() []*Result {
+	r := make([]*Result, 0, len(i.lines))
+	for k := range i.lines {
+		r = append(r, &Result{Name: i.names[k], Chart: i.charts[k], key: k, termFreq: i.termFreqs[k], docLen: i.docLens[k]})
+	}
+	return r
+}
+
+// Query describes a single search against an Index: the query text plus the
+// options controlling how it's matched. Search builds one of these from its
+// positional arguments for backward compatibility; SearchQuery is the
+// general entry point new callers should prefer.
+type Query struct {
+	// Term is the raw query text, including any field-scoped clauses and
+	// trailing "@constraint".
+	Term string
+	// Regexp treats Term's clauses as regular expressions instead of
+	// literal substrings.
+	Regexp bool
+	// Fuzzy enables typo-tolerant matching via a bounded edit distance
+	// (see MaxEdits). Mutually exclusive with Regexp.
+	Fuzzy bool
+	// MaxEdits caps the Levenshtein distance a fuzzy match may have from
+	// the query term. Defaults to 2 when Fuzzy is set and MaxEdits is 0.
+	MaxEdits int
+
+	// idf and avgDocLen carry the corpus-wide stats a Scorer like BM25Scorer
+	// needs to rank. SearchQuery populates them right before scoring so a
+	// Scorer never needs a reference back to the Index.
+	idf       map[string]float64
+	avgDocLen float64
+}
+
+// Search searches the index for charts matching name, returning a Result per
+// match along with its relevance score (lower is better).
+//
+// Set regexp to true to treat name as a regular expression instead of a
+// literal substring.
+//
+// name may carry a trailing Masterminds/semver constraint introduced by "@",
+// e.g. "testing/santa-maria@>=1.2.0,<2.0.0". When present, results are
+// narrowed to the chart versions satisfying it: every satisfying version is
+// kept for a chart indexed with all=true, while a chart indexed with
+// all=false reports its highest satisfying version (or no result at all,
+// rather than falling back to the latest stable release).
+//
+// name also accepts field-scoped clauses, e.g. "keyword:database
+// name:postgres -maintainer:bitnami" or a quoted phrase like
+// description:"highly available". Unqualified terms keep matching name and
+// description as before; regexp applies to every clause individually.
+//
+// threshold is accepted for backward compatibility but unused; see
+// SearchQuery for fuzzy matching.
+func (i Index) Search(name string, threshold int, regexp bool) ([]*Result, error) {
+	return i.SearchQuery(Query{Term: name, Regexp: regexp})
+}
+
+// SearchQuery runs q against the index. It generalizes Search with
+// typo-tolerant fuzzy matching: see Query.Fuzzy.
+func (i Index) SearchQuery(q Query) ([]*Result, error) {
+	if q.Fuzzy && q.Regexp {
+		return nil, errFuzzyRegexp
+	}
+
+	name, constraint, err := splitConstraint(q.Term)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*Result
+	if q.Fuzzy {
+		maxEdits := q.MaxEdits
+		if maxEdits == 0 {
+			maxEdits = defaultMaxEdits
+		}
+		results, err = i.fuzzySearch(name, maxEdits)
+	} else {
+		var clauses []clause
+		clauses, err = parseQuery(name)
+		if err == nil {
+			results, err = i.searchClauses(clauses, q.Regexp)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if constraint != nil {
+		if results, err = i.constrain(results, constraint); err != nil {
+			return nil, err
+		}
+	} else {
+		// Without a constraint, a chart indexed with all=true has one
+		// document per version, so an unconstrained query can otherwise
+		// match the same chart several times over. Collapse those down to
+		// a single Result, the way an all=false index would have reported
+		// it; constrain above already does its own chart-level dedup for
+		// the constrained case.
+		results = dedupeByName(results)
+	}
+
+	i.score(results, q)
+	return results, nil
+}
+
+// dedupeByName collapses results naming the same chart down to the single
+// best match, keeping insertion order otherwise. Ties are broken in favor of
+// the newer chart version, mirroring scoreSorter's tie-break.
+func dedupeByName(results []*Result) []*Result {
+	best := make(map[string]*Result, len(results))
+	order := make([]string, 0, len(results))
+	for _, r := range results {
+		cur, ok := best[r.Name]
+		if !ok {
+			order = append(order, r.Name)
+			best[r.Name] = r
+			continue
+		}
+		if betterResult(r, cur) {
+			best[r.Name] = r
+		}
+	}
+
+	out := make([]*Result, 0, len(order))
+	for _, name := range order {
+		out = append(out, best[name])
+	}
+	return out
+}
+
+// betterResult reports whether candidate should replace incumbent as the
+// representative Result for a chart: a lower score wins outright, and a tie
+// is broken by preferring the newer chart version.
+func betterResult(candidate, incumbent *Result) bool {
+	if candidate.Score != incumbent.Score {
+		return candidate.Score < incumbent.Score
+	}
+	if candidate.Chart == nil || incumbent.Chart == nil {
+		return false
+	}
+	cv, err1 := semver.NewVersion(candidate.Chart.Version)
+	iv, err2 := semver.NewVersion(incumbent.Chart.Version)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return cv.GreaterThan(iv)
+}
+
+// score runs every result through i.scorer, writing the outcome back to
+// both Score (so SortScore and existing callers keep working unchanged) and
+// RelevanceScore (the unrounded value, for callers that want it).
+func (i Index) score(results []*Result, q Query) {
+	scorer := i.scorer
+	if scorer == nil {
+		scorer = positionScorer{}
+	}
+
+	q.idf = i.idfMap(wordsOf(q.Term))
+	q.avgDocLen = i.avgDocLen()
+	for _, r := range results {
+		rel := scorer.Score(r, q)
+		r.RelevanceScore = rel
+		r.Score = int(math.Round(rel))
+	}
+}
+
+func (i Index) searchClauses(clauses []clause, useRegexp bool) ([]*Result, error) {
+	keys, err := i.candidateKeys(clauses, useRegexp)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*Result
+	for _, k := range keys {
+		line, ok := i.lines[k]
+		if !ok {
+			continue
+		}
+		score, matched, err := i.evalClauses(k, line, clauses, useRegexp)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			out = append(out, &Result{Name: i.names[k], Score: score, Chart: i.charts[k], key: k, termFreq: i.termFreqs[k], docLen: i.docLens[k]})
+		}
+	}
+	return out, nil
+}
+
+// evalClauses reports whether every clause matches the document at key,
+// along with the worst (highest) match position across its non-negated
+// clauses, for use as the result's score.
+func (i Index) evalClauses(key, line string, clauses []clause, useRegexp bool) (int, bool, error) {
+	score := 0
+	for _, c := range clauses {
+		text := line
+		if c.field != "" {
+			text = i.fieldText(key, c.field)
+		}
+
+		ok, idx, err := matchTerm(text, c.term, useRegexp)
+		if err != nil {
+			return 0, false, err
+		}
+		if c.negated {
+			ok = !ok
+		}
+		if !ok {
+			return 0, false, nil
+		}
+		if !c.negated && idx > score {
+			score = idx
+		}
+	}
+	return score, true, nil
+}
+
+// fieldText returns the text a field-scoped clause should be matched
+// against; unrecognized fields fall back to the unqualified name+description
+// blob so a typo in a field name degrades gracefully instead of matching
+// nothing.
+func (i Index) fieldText(key, field string) string {
+	f := i.fields[key]
+	switch field {
+	case "name":
+		return f.name
+	case "description", "desc":
+		return f.description
+	case "keyword", "keywords":
+		return f.keywords
+	case "maintainer", "maintainers":
+		return f.maintainers
+	case "source", "sources":
+		return f.sources
+	case "home":
+		return f.home
+	case "appversion", "app-version":
+		return f.appVersion
+	default:
+		return i.lines[key]
+	}
+}
+
+func matchTerm(text, term string, useRegexp bool) (bool, int, error) {
+	if useRegexp {
+		// text is always lowercased (see indstr/fieldsOf), so match
+		// case-insensitively rather than requiring callers to lowercase
+		// their patterns too.
+		re, err := regexp.Compile("(?i)" + term)
+		if err != nil {
+			return false, 0, err
+		}
+		loc := re.FindStringIndex(text)
+		if loc == nil {
+			return false, 0, nil
+		}
+		return true, loc[0], nil
+	}
+
+	idx := strings.Index(text, strings.ToLower(term))
+	return idx > -1, idx, nil
+}
+
+// splitConstraint pulls an "@constraint" suffix off of query, if present.
+func splitConstraint(query string) (string, *semver.Constraints, error) {
+	name, raw, found := strings.Cut(query, "@")
+	if !found {
+		return query, nil, nil
+	}
+	c, err := semver.NewConstraint(raw)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid version constraint %q: %w", raw, err)
+	}
+	return name, c, nil
+}
+
+// constrain narrows results down to the chart versions satisfying
+// constraint, using the full version history AddRepo recorded for each
+// chart rather than just whatever version Search happened to match.
+func (i Index) constrain(results []*Result, constraint *semver.Constraints) ([]*Result, error) {
+	seen := map[string]bool{}
+	out := make([]*Result, 0, len(results))
+	for _, r := range results {
+		if seen[r.Name] {
+			continue
+		}
+		seen[r.Name] = true
+
+		var best *repo.ChartVersion
+		var bestVer *semver.Version
+		for _, ctv := range i.versions[r.Name] {
+			v, err := semver.NewVersion(ctv.Version)
+			if err != nil || !constraint.Check(v) {
+				continue
+			}
+
+			if i.expanded[r.Name] {
+				key := i.versionKey(r.Name, ctv)
+				out = append(out, &Result{Name: r.Name, Score: r.Score, Chart: ctv, key: key, termFreq: i.termFreqs[key], docLen: i.docLens[key]})
+				continue
+			}
+			if best == nil || v.GreaterThan(bestVer) {
+				best, bestVer = ctv, v
+			}
+		}
+		if !i.expanded[r.Name] && best != nil {
+			key := i.versionKey(r.Name, best)
+			out = append(out, &Result{Name: r.Name, Score: r.Score, Chart: best, key: key, termFreq: i.termFreqs[key], docLen: i.docLens[key]})
+		}
+	}
+	return out, nil
+}
+
+// versionKey reconstructs the i.charts lookup key addVersion assigned to
+// ctv, the chart named chartName's first indexed version bare, later ones
+// suffixed with their version.
+func (i Index) versionKey(chartName string, ctv *repo.ChartVersion) string {
+	if i.charts[chartName] == ctv {
+		return chartName
+	}
+	return fmt.Sprintf("%s-%s", chartName, ctv.Version)
+}
+
+// SortScore does an in-place sort of the results.
+//
+// Results are sorted by ascending score, with ties broken by ascending
+// RelevanceScore (the unrounded score), then alphabetically by name and, for
+// same-named results, by descending chart version so the newest version
+// sorts first.
+func SortScore(r []*Result) {
+	sort.Sort(scoreSorter(r))
+}
+
+type scoreSorter []*Result
+
+func (s scoreSorter) Len() int      { return len(s) }
+func (s scoreSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s scoreSorter) Less(i, j int) bool {
+	if s[i].Score != s[j].Score {
+		return s[i].Score < s[j].Score
+	}
+	if s[i].RelevanceScore != s[j].RelevanceScore {
+		return s[i].RelevanceScore < s[j].RelevanceScore
+	}
+	if s[i].Name != s[j].Name {
+		return s[i].Name < s[j].Name
+	}
+	if s[i].Chart == nil || s[j].Chart == nil {
+		return false
+	}
+	iv, err1 := semver.NewVersion(s[i].Chart.Version)
+	jv, err2 := semver.NewVersion(s[j].Chart.Version)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return jv.LessThan(iv)
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// OCILister discovers charts published to an OCI registry so AddOCIRegistry
+// can index them alongside classic HTTP chart repositories.
+//
+// Implementations typically wrap helm.sh/helm/v3/pkg/registry.Client, which
+// already knows how to call the Distribution "_catalog" and "tags/list"
+// endpoints and to pull chart.Metadata out of an OCI artifact's config blob;
+// tests can supply a fake backed by an in-memory catalog instead.
+type OCILister interface {
+	// ListRepositories returns every repository path hosted at ref, e.g.
+	// the result of a Distribution "_catalog" call.
+	ListRepositories(ctx context.Context, ref string) ([]string, error)
+	// ListTags returns every tag published under repository.
+	ListTags(ctx context.Context, repository string) ([]string, error)
+	// ChartMetadata resolves repository:tag's manifest and returns the
+	// chart.Metadata recorded in its config blob.
+	ChartMetadata(ctx context.Context, repository, tag string) (*chart.Metadata, error)
+}
+
+// AddOCIRegistry discovers every chart hosted in the OCI registry at ref and
+// indexes them exactly as AddRepo indexes a classic repo.IndexFile: one
+// searchable entry per chart, or one per version when all is true.
+//
+// name is the local label charts are indexed under, mirroring AddRepo's
+// rname. Each indexed chart's URL reports "oci://ref/repository" so
+// downstream tooling can helm pull it directly.
+func (i *Index) AddOCIRegistry(ctx context.Context, name, ref string, lister OCILister, all bool) error {
+	repositories, err := lister.ListRepositories(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("listing OCI repositories at %s: %w", ref, err)
+	}
+
+	entries := map[string]repo.ChartVersions{}
+	for _, repository := range repositories {
+		ctvs, err := i.ociChartVersions(ctx, ref, repository, lister)
+		if err != nil {
+			return err
+		}
+		if len(ctvs) == 0 {
+			continue
+		}
+		entries[repository] = ctvs
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ind := &repo.IndexFile{Entries: entries}
+	ind.SortEntries()
+	i.AddRepo(name, ind, all)
+	return nil
+}
+
+// ociChartVersions resolves every tag published under repository into a
+// repo.ChartVersion, so AddOCIRegistry can feed the result straight into
+// AddRepo.
+func (i *Index) ociChartVersions(ctx context.Context, ref, repository string, lister OCILister) (repo.ChartVersions, error) {
+	tags, err := lister.ListTags(ctx, repository)
+	if err != nil {
+		return nil, fmt.Errorf("listing tags for %s: %w", repository, err)
+	}
+
+	ctvs := make(repo.ChartVersions, 0, len(tags))
+	for _, tag := range tags {
+		meta, err := lister.ChartMetadata(ctx, repository, tag)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s:%s: %w", repository, tag, err)
+		}
+		ctvs = append(ctvs, &repo.ChartVersion{
+			Metadata: meta,
+			URLs:     []string{fmt.Sprintf("oci://%s/%s", ref, repository)},
+		})
+	}
+	return ctvs, nil
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// fakeOCIRegistry is a minimal in-memory OCILister standing in for an OCI
+// Distribution registry, keyed by repository -> tag -> chart.Metadata.
+type fakeOCIRegistry map[string]map[string]*chart.Metadata
+
+func (f fakeOCIRegistry) ListRepositories(_ context.Context, _ string) ([]string, error) {
+	repositories := make([]string, 0, len(f))
+	for r := range f {
+		repositories = append(repositories, r)
+	}
+	return repositories, nil
+}
+
+func (f fakeOCIRegistry) ListTags(_ context.Context, repository string) ([]string, error) {
+	tags, ok := f[repository]
+	if !ok {
+		return nil, fmt.Errorf("no such repository %q", repository)
+	}
+	out := make([]string, 0, len(tags))
+	for t := range tags {
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (f fakeOCIRegistry) ChartMetadata(_ context.Context, repository, tag string) (*chart.Metadata, error) {
+	meta, ok := f[repository][tag]
+	if !ok {
+		return nil, fmt.Errorf("no such tag %s:%s", repository, tag)
+	}
+	return meta, nil
+}
+
+func TestAddOCIRegistry(t *testing.T) {
+	fake := fakeOCIRegistry{
+		"charts/postgres": {
+			"1.0.0": {Name: "postgres", Version: "1.0.0", Description: "An object-relational database"},
+		},
+	}
+
+	i := NewIndex()
+	if err := i.AddOCIRegistry(context.Background(), "myregistry", "registry.example.com", fake, false); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := i.Search("postgres", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+
+	r := results[0]
+	if r.Name != "myregistry/charts/postgres" {
+		t.Errorf("expected name myregistry/charts/postgres, got %s", r.Name)
+	}
+	wantURL := "oci://registry.example.com/charts/postgres"
+	if len(r.Chart.URLs) != 1 || r.Chart.URLs[0] != wantURL {
+		t.Errorf("expected URL %s, got %v", wantURL, r.Chart.URLs)
+	}
+}
+
+func TestAddOCIRegistryEmptyCatalog(t *testing.T) {
+	i := NewIndex()
+	if err := i.AddOCIRegistry(context.Background(), "myregistry", "registry.example.com", fakeOCIRegistry{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if all := i.All(); len(all) != 0 {
+		t.Errorf("expected no entries, got %d", len(all))
+	}
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// clause is a single field-scoped term extracted from a search query.
+//
+// field is empty for unqualified terms, which Index.Search matches against a
+// chart's combined name and description, preserving the original
+// single-blob search behavior.
+type clause struct {
+	field   string
+	term    string
+	negated bool
+}
+
+// queryFields lists the field names parseQuery recognizes as a "field:"
+// prefix rather than part of an unqualified term.
+var queryFields = map[string]bool{
+	"name":        true,
+	"description": true,
+	"desc":        true,
+	"keyword":     true,
+	"keywords":    true,
+	"maintainer":  true,
+	"maintainers": true,
+	"source":      true,
+	"sources":     true,
+	"home":        true,
+	"appversion":  true,
+	"app-version": true,
+}
+
+// parseQuery splits a query into clauses, recognizing "field:term",
+// negation via a leading "-" (e.g. "-keyword:foo"), and quoted phrases
+// (e.g. description:"highly available").
+//
+// A token whose prefix isn't one of queryFields is treated as a plain
+// unqualified term, so terms like "notafield:postgres" that happen to
+// contain a colon keep matching exactly as before.
+func parseQuery(q string) ([]clause, error) {
+	tokens, err := tokenizeQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	clauses := make([]clause, 0, len(tokens))
+	for _, tok := range tokens {
+		negated := strings.HasPrefix(tok, "-")
+		stripped := tok
+		if negated {
+			stripped = tok[1:]
+		}
+
+		field, term, found := strings.Cut(stripped, ":")
+		if !found || !queryFields[strings.ToLower(field)] {
+			// Not a recognized field clause after all: keep the token
+			// (including any leading "-") as a plain unqualified term.
+			clauses = append(clauses, clause{term: tok})
+			continue
+		}
+		clauses = append(clauses, clause{field: strings.ToLower(field), term: term, negated: negated})
+	}
+	return clauses, nil
+}
+
+// tokenizeQuery splits q on space characters (tabs and newlines are not
+// separators), treating a double-quoted span as a
+// single token so phrases like description:"highly available" survive with
+// their embedded space intact.
+func tokenizeQuery(q string) ([]string, error) {
+	var tokens []string
+	var b strings.Builder
+	inQuotes := false
+
+	flush := func() {
+		if b.Len() > 0 {
+			tokens = append(tokens, b.String())
+			b.Reset()
+		}
+	}
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ' ' && !inQuotes:
+			flush()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("search: unterminated quote in query %q", q)
+	}
+	flush()
+	return tokens, nil
+}
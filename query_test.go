@@ -0,0 +1,68 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		expect []clause
+		fail   bool
+	}{
+		{
+			name:   "unqualified term",
+			query:  "postgres",
+			expect: []clause{{term: "postgres"}},
+		},
+		{
+			name:  "field-scoped terms",
+			query: "keyword:database name:postgres maintainer:bitnami",
+			expect: []clause{
+				{field: "keyword", term: "database"},
+				{field: "name", term: "postgres"},
+				{field: "maintainer", term: "bitnami"},
+			},
+		},
+		{
+			name:   "negated field",
+			query:  "-keyword:foo",
+			expect: []clause{{field: "keyword", term: "foo", negated: true}},
+		},
+		{
+			name:   "quoted phrase",
+			query:  `description:"highly available"`,
+			expect: []clause{{field: "description", term: "highly available"}},
+		},
+		{
+			name:   "unrecognized field falls back to unqualified",
+			query:  "notafield:postgres",
+			expect: []clause{{term: "notafield:postgres"}},
+		},
+		{
+			name:  "unterminated quote",
+			query: `description:"oops`,
+			fail:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clauses, err := parseQuery(tt.query)
+			if tt.fail {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(clauses, tt.expect) {
+				t.Errorf("expected %+v, got %+v", tt.expect, clauses)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package search
+
+import (
+	"math"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Scorer computes a relevance score for a single search result.
+//
+// Lower scores are better matches, consistent with the position-based
+// scores Search has always produced; SortScore sorts ascending.
+type Scorer interface {
+	Score(result *Result, query Query) float64
+}
+
+// positionScorer is the default Scorer, kept for backward compatibility: it
+// simply reports whatever position-based score searchClauses/fuzzySearch
+// already computed for the result.
+type positionScorer struct{}
+
+func (positionScorer) Score(result *Result, _ Query) float64 {
+	return float64(result.Score)
+}
+
+// BM25Scorer ranks results with Okapi BM25 over three weighted fields - name,
+// keywords, and description - treating each chart as a document whose term
+// frequencies were computed once at AddRepo time (see indexBM25Stats) and
+// whose inverse document frequencies are computed across the whole index
+// right before scoring (see Index.score).
+//
+// Because BM25 scores higher for a better match and Scorer follows Search's
+// lower-is-better convention, Score negates the raw BM25 value.
+type BM25Scorer struct {
+	// K1 controls term frequency saturation; 1.2 is the usual default.
+	K1 float64
+	// B controls how strongly document length normalizes term frequency;
+	// 0.75 is the usual default.
+	B float64
+}
+
+// NewBM25Scorer returns a BM25Scorer with the conventional K1=1.2, B=0.75.
+func NewBM25Scorer() *BM25Scorer {
+	return &BM25Scorer{K1: 1.2, B: 0.75}
+}
+
+func (s *BM25Scorer) Score(result *Result, query Query) float64 {
+	k1, b := s.K1, s.B
+	if k1 == 0 && b == 0 {
+		k1, b = 1.2, 0.75
+	}
+
+	var score float64
+	for _, term := range wordsOf(query.Term) {
+		tf := result.termFreq[term]
+		if tf == 0 {
+			continue
+		}
+		idf := query.idf[term]
+		denom := tf + k1*(1-b+b*result.docLen/query.avgDocLen)
+		score += idf * (tf * (k1 + 1) / denom)
+	}
+	return -score
+}
+
+// fieldWeight mirrors BM25Scorer's field weighting when indexBM25Stats
+// builds each document's term frequencies: name counts most, keywords less,
+// description least.
+const (
+	nameWeight        = 5.0
+	keywordWeight     = 3.0
+	descriptionWeight = 1.0
+)
+
+// indexBM25Stats computes key's weighted term frequencies and total field
+// length, and folds its vocabulary into the index's document-frequency and
+// average-length stats used to compute IDF at search time.
+func (i *Index) indexBM25Stats(key, name string, ctv *repo.ChartVersion) {
+	freq := map[string]float64{}
+	add := func(text string, weight float64) {
+		for _, w := range wordsOf(text) {
+			freq[w] += weight
+		}
+	}
+	add(name, nameWeight)
+	for _, kw := range ctv.Keywords {
+		add(kw, keywordWeight)
+	}
+	add(ctv.Description, descriptionWeight)
+
+	var length float64
+	for term, weight := range freq {
+		length += weight
+		i.docFreq[term]++
+	}
+
+	i.termFreqs[key] = freq
+	i.docLens[key] = length
+	i.totalDocLen += length
+	i.docCount++
+}
+
+// idfMap computes the standard BM25 inverse document frequency for each of
+// terms, using the corpus size and per-term document frequency recorded by
+// indexBM25Stats.
+func (i Index) idfMap(terms []string) map[string]float64 {
+	n := float64(i.docCount)
+	idf := make(map[string]float64, len(terms))
+	for _, t := range terms {
+		df := float64(i.docFreq[t])
+		idf[t] = math.Log((n-df+0.5)/(df+0.5) + 1)
+	}
+	return idf
+}
+
+// avgDocLen returns the corpus's average weighted document length, the
+// length-normalization baseline BM25 compares each document against.
+func (i Index) avgDocLen() float64 {
+	if i.docCount == 0 {
+		return 0
+	}
+	return i.totalDocLen / float64(i.docCount)
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func loadScorerTestIndex(_ *testing.T, scorer Scorer) *Index {
+	i := NewIndexWithScorer(scorer)
+	i.AddRepo("testing", &repo.IndexFile{Entries: map[string]repo.ChartVersions{
+		"postgres": {
+			{
+				URLs: []string{"http://example.com/charts/postgres-1.0.0.tgz"},
+				Metadata: &chart.Metadata{
+					Name:        "postgres",
+					Version:     "1.0.0",
+					Description: "A relational database",
+					Keywords:    []string{"database", "sql"},
+				},
+			},
+		},
+		"pg-operator": {
+			{
+				URLs: []string{"http://example.com/charts/pg-operator-1.0.0.tgz"},
+				Metadata: &chart.Metadata{
+					Name:        "pg-operator",
+					Version:     "1.0.0",
+					Description: "Operator for running a postgres database on Kubernetes, with backups and monitoring included",
+					Keywords:    []string{"database", "sql", "operator"},
+				},
+			},
+		},
+	}}, false)
+	return i
+}
+
+func TestBM25ScorerRanksNameMatchAboveDescriptionOnlyMatch(t *testing.T) {
+	i := loadScorerTestIndex(t, NewBM25Scorer())
+	results, err := i.SearchQuery(Query{Term: "postgres"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	SortScore(results)
+
+	if results[0].Name != "testing/postgres" {
+		t.Errorf("expected testing/postgres to rank first (name match), got %s", results[0].Name)
+	}
+}
+
+func TestNewIndexWithScorerDefaultsToPositionScorer(t *testing.T) {
+	i := loadScorerTestIndex(t, nil)
+	results, err := i.SearchQuery(Query{Term: "postgres"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.RelevanceScore != float64(r.Score) {
+			t.Errorf("expected RelevanceScore to mirror Score under the default scorer, got %v != %v", r.RelevanceScore, r.Score)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package search
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// trigramLen is the fixed window size used to build and query the posting
+// lists, following the approach described in Russ Cox's "Regular Expression
+// Matching with a Trigram Index" (used by Google Code Search and Zoekt).
+const trigramLen = 3
+
+// indexTrigrams records every trigram of text against key, so later queries
+// can narrow their candidate set to documents that could possibly contain a
+// given term before running the exact substring/regex check.
+func (i *Index) indexTrigrams(key, text string) {
+	for _, tg := range trigramsOf(text) {
+		postings := i.postings[tg]
+		if n := len(postings); n > 0 && postings[n-1] == key {
+			continue
+		}
+		i.postings[tg] = append(i.postings[tg], key)
+	}
+}
+
+// trigramsOf returns the unique trigrams of text, after lowercasing and
+// Unicode NFC normalization so combining-character variants of the same
+// text (e.g. "nina" spelled with a combining tilde vs. a precomposed ñ)
+// produce identical trigrams.
+func trigramsOf(text string) []string {
+	runes := []rune(norm.NFC.String(strings.ToLower(text)))
+	if len(runes) < trigramLen {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var out []string
+	for i := 0; i+trigramLen <= len(runes); i++ {
+		tg := string(runes[i : i+trigramLen])
+		if !seen[tg] {
+			seen[tg] = true
+			out = append(out, tg)
+		}
+	}
+	return out
+}
+
+// candidateKeys returns the documents worth an exact check for clauses,
+// narrowed using the trigram index when possible. It scans every non-negated
+// clause (a negated clause can only shrink a result set by exclusion, so it
+// can't usefully narrow one) for required trigrams, stopping at the first
+// clause that yields any; if none do - every term is shorter than a
+// trigram, or every candidate clause is negated - every indexed document is
+// returned, matching the pre-trigram behavior.
+func (i Index) candidateKeys(clauses []clause, useRegexp bool) ([]string, error) {
+	for _, c := range clauses {
+		if c.negated {
+			continue
+		}
+
+		var tgs []string
+		if useRegexp {
+			required, ok := requiredTrigrams(c.term)
+			if !ok {
+				continue
+			}
+			tgs = required
+		} else {
+			tgs = trigramsOf(c.term)
+			if tgs == nil {
+				continue
+			}
+		}
+		return i.intersectPostings(tgs), nil
+	}
+	return i.allKeys(), nil
+}
+
+func (i Index) intersectPostings(tgs []string) []string {
+	sort.Strings(tgs)
+	var candidates []string
+	for n, tg := range tgs {
+		if n == 0 {
+			candidates = append(candidates, i.postings[tg]...)
+			continue
+		}
+		candidates = intersectSorted(candidates, i.postings[tg])
+	}
+	return candidates
+}
+
+func intersectSorted(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, k := range b {
+		inB[k] = true
+	}
+	out := make([]string, 0, len(a))
+	for _, k := range a {
+		if inB[k] {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func (i Index) allKeys() []string {
+	keys := make([]string, 0, len(i.lines))
+	for k := range i.lines {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// requiredTrigrams extracts the trigrams of the longest literal run pattern
+// is guaranteed to contain, for use as a prefilter. Patterns with no
+// provably-required literal substring of at least trigramLen runes (e.g.
+// "Th[ref]*", which could match as little as "Th") return ok=false, telling
+// the caller to fall back to scanning every document.
+func requiredTrigrams(pattern string) (tgs []string, ok bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+
+	literal := longestRequiredLiteral(re)
+	if len([]rune(literal)) < trigramLen {
+		return nil, false
+	}
+	return trigramsOf(literal), true
+}
+
+// longestRequiredLiteral walks a regexp AST looking for the longest run of
+// runes it can prove must appear verbatim in any match, e.g. "-operator" out
+// of "helm-.*-operator" (the ".*" breaks the literal run; only the longer of
+// the two literal pieces is returned, so "helm-" is not used as a filter).
+// It only trusts OpLiteral nodes and
+// concatenations of them; alternation, repetition, and anything else that
+// doesn't guarantee a fixed substring stops the current run.
+func longestRequiredLiteral(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune)
+	case syntax.OpConcat:
+		var best, cur string
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				cur += string(sub.Rune)
+				if len(cur) > len(best) {
+					best = cur
+				}
+				continue
+			}
+			cur = ""
+		}
+		return best
+	default:
+		return ""
+	}
+}
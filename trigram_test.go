@@ -0,0 +1,92 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestTrigramsOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		expect []string
+	}{
+		{name: "too short", text: "ab", expect: nil},
+		{name: "exact trigram", text: "abc", expect: []string{"abc"}},
+		{name: "overlapping", text: "abcd", expect: []string{"abc", "bcd"}},
+		{name: "case folded", text: "ABC", expect: []string{"abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trigramsOf(tt.text)
+			if fmt.Sprint(got) != fmt.Sprint(tt.expect) {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestRequiredTrigrams(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		ok      bool
+	}{
+		{name: "wildcard tail leaves a required literal shorter than a trigram", pattern: "Th[ref]*", ok: false},
+		{name: "literal run around a wildcard is required", pattern: "helm-.*-operator", ok: true},
+		{name: "short literal stays below trigram length", pattern: "ab.*cd", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := requiredTrigrams(tt.pattern)
+			if ok != tt.ok {
+				t.Errorf("expected ok=%v, got %v", tt.ok, ok)
+			}
+		})
+	}
+}
+
+func seedIndex(b *testing.B, n int) *Index {
+	b.Helper()
+	idx := NewIndex()
+	entries := make(map[string]repo.ChartVersions, n)
+	for j := 0; j < n; j++ {
+		name := fmt.Sprintf("chart-%d-operator", j)
+		entries[name] = repo.ChartVersions{
+			{
+				URLs: []string{fmt.Sprintf("http://example.com/charts/%s-1.0.0.tgz", name)},
+				Metadata: &chart.Metadata{
+					Name:        name,
+					Version:     "1.0.0",
+					Description: fmt.Sprintf("synthetic chart number %d for benchmarking", j),
+					Keywords:    []string{"synthetic", "benchmark"},
+				},
+			},
+		}
+	}
+	idx.AddRepo("bench", &repo.IndexFile{Entries: entries}, false)
+	return idx
+}
+
+func BenchmarkSearchLiteral(b *testing.B) {
+	idx := seedIndex(b, 5000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := idx.Search("chart-4999-operator", 100, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSearchRegexp(b *testing.B) {
+	idx := seedIndex(b, 5000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := idx.Search("chart-.*-operator", 100, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}